@@ -0,0 +1,94 @@
+package workerpool
+
+import (
+	"strings"
+	"time"
+
+	"github.com/douyu/juno/pkg/model/db"
+	"github.com/douyu/jupiter/pkg/xlog"
+)
+
+// flushHeartbeats drains w.heartbeatCh into batches and writes each batch as
+// a single upsert, either every HeartbeatFlushInterval or as soon as
+// HeartbeatBatchSize entries have accumulated, whichever comes first.
+func (w *WorkerPool) flushHeartbeats() {
+	interval := w.option.HeartbeatFlushInterval
+	if interval <= 0 {
+		interval = defaultHeartbeatFlushInterval
+	}
+
+	batchSize := w.option.HeartbeatBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultHeartbeatBatchSize
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]db.WorkerNode, 0, batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		w.writeHeartbeatBatch(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case node := <-w.heartbeatCh:
+			batch = append(batch, node)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// writeHeartbeatBatch upserts every node in one statement built by
+// buildHeartbeatUpsert.
+func (w *WorkerPool) writeHeartbeatBatch(batch []db.WorkerNode) {
+	query, args := buildHeartbeatUpsert(batch)
+
+	if err := w.option.DB.Exec(query, args...).Error; err != nil {
+		xlog.Error("WorkerPool.writeHeartbeatBatch", xlog.String("err", err.Error()), xlog.Int("batchSize", len(batch)))
+	}
+}
+
+// buildHeartbeatUpsert renders batch as one statement:
+//
+//	INSERT INTO worker_node (...) VALUES (...), (...), ...
+//	ON DUPLICATE KEY UPDATE last_heartbeat=VALUES(last_heartbeat), ip=VALUES(ip), ...
+//
+// which requires the unique index on (host_name, zone_code, env,
+// region_code) declared on db.WorkerNode.
+func buildHeartbeatUpsert(batch []db.WorkerNode) (string, []interface{}) {
+	columns := []string{
+		"host_name", "region_code", "region_name", "zone_code", "zone_name", "env",
+		"ip", "port", "version", "labels", "inflight_jobs", "cpu", "mem_percent", "last_heartbeat",
+	}
+
+	placeholders := make([]string, 0, len(batch))
+	args := make([]interface{}, 0, len(batch)*len(columns))
+
+	for _, node := range batch {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+		args = append(args,
+			node.HostName, node.RegionCode, node.RegionName, node.ZoneCode, node.ZoneName, node.Env,
+			node.IP, node.Port, node.Version, node.Labels, node.Heartbeat.InflightJobs, node.Heartbeat.CPU, node.Heartbeat.MemPercent,
+			node.LastHeartbeat,
+		)
+	}
+
+	query := "INSERT INTO " + db.WorkerNode{}.TableName() + " (" + strings.Join(columns, ", ") + ") VALUES " +
+		strings.Join(placeholders, ", ") +
+		" ON DUPLICATE KEY UPDATE region_name=VALUES(region_name), zone_name=VALUES(zone_name)," +
+		" ip=VALUES(ip), port=VALUES(port), version=VALUES(version), labels=VALUES(labels)," +
+		" inflight_jobs=VALUES(inflight_jobs)," +
+		" cpu=VALUES(cpu), mem_percent=VALUES(mem_percent), last_heartbeat=VALUES(last_heartbeat)"
+
+	return query, args
+}
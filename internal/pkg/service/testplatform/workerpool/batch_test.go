@@ -0,0 +1,49 @@
+package workerpool
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/douyu/juno/pkg/model/db"
+)
+
+func TestBuildHeartbeatUpsert(t *testing.T) {
+	batch := []db.WorkerNode{
+		{HostName: "h1", ZoneCode: "z1", Env: "prod", Version: "1.2.0", Labels: db.StringMap{"arch": "arm64"}, LastHeartbeat: time.Unix(0, 0)},
+		{HostName: "h2", ZoneCode: "z1", Env: "staging", Version: "1.3.0", LastHeartbeat: time.Unix(0, 0)},
+	}
+
+	query, args := buildHeartbeatUpsert(batch)
+
+	if !strings.Contains(query, "ON DUPLICATE KEY UPDATE") {
+		t.Fatalf("expected an upsert clause, got: %s", query)
+	}
+	if !strings.Contains(query, "version=VALUES(version)") {
+		t.Fatalf("expected version to be part of the upsert, got: %s", query)
+	}
+	if !strings.Contains(query, "labels=VALUES(labels)") {
+		t.Fatalf("expected labels to be part of the upsert, got: %s", query)
+	}
+	if !strings.Contains(query, " env,") {
+		t.Fatalf("expected env to be part of the insert columns (it's part of idx_worker_node_identity), got: %s", query)
+	}
+	if got, want := strings.Count(query, "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"), len(batch); got != want {
+		t.Fatalf("expected %d value tuples, got %d", want, got)
+	}
+
+	const columnsPerRow = 14
+	if got, want := len(args), len(batch)*columnsPerRow; got != want {
+		t.Fatalf("expected %d args, got %d", want, got)
+	}
+	if args[0] != "h1" || args[columnsPerRow] != "h2" {
+		t.Fatalf("expected host_name to be the first column of each row, got args: %v", args)
+	}
+	if args[5] != "prod" || args[columnsPerRow+5] != "staging" {
+		t.Fatalf("expected env to be the 6th column of each row, got args: %v", args)
+	}
+	labels, ok := args[9].(db.StringMap)
+	if !ok || labels["arch"] != "arm64" {
+		t.Fatalf("expected labels to be the 10th column of each row, got args: %v", args)
+	}
+}
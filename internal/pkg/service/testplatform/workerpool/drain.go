@@ -0,0 +1,142 @@
+package workerpool
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/douyu/juno/pkg/model/db"
+)
+
+// State is a WorkerNode's place in a rolling upgrade or drain. It's
+// operator-controlled, not reported by the worker itself.
+type State string
+
+const (
+	StateActive   State = "active"
+	StateDraining State = "draining"
+	StateStopping State = "stopping"
+)
+
+// NodeStatus is a read-only snapshot of one node for topology/drain-state
+// UIs; unlike db.WorkerNode it's not a gorm model.
+type NodeStatus struct {
+	HostName  string
+	IP        string
+	Port      int
+	Version   string
+	State     State
+	Heartbeat db.WorkerLoad
+}
+
+// Drain pulls hostName out of Select's rotation without killing it: it
+// keeps heartbeating and stays listed by Nodes, but selector.pick(),
+// SelectByKey, SelectWithConstraints and SelectVersion all skip it for new
+// work. It's the caller's job to keep
+// heartbeating with Drain in effect until the process actually stops.
+func (w *WorkerPool) Drain(hostName, zoneCode, env string) error {
+	w.nodesMtx.Lock()
+	selector, ok := w.selectorFor(zoneCode, env)
+	if !ok {
+		w.nodesMtx.Unlock()
+		return ErrNodesEmpty
+	}
+
+	node, ok := selector.get(hostName)
+	if !ok {
+		w.nodesMtx.Unlock()
+		return ErrNodesEmpty
+	}
+
+	node.State = string(StateDraining)
+	selector.push(node)
+	w.nodesMtx.Unlock()
+
+	return w.option.DB.Model(&db.WorkerNode{}).
+		Where("host_name = ? and zone_code = ? and env = ?", hostName, zoneCode, env).
+		Update("state", string(StateDraining)).Error
+}
+
+// SelectVersion picks among the nodes in zoneCode/env whose Version is at
+// least minVersion, using dotted numeric version strings (e.g. "1.12.0").
+func (w *WorkerPool) SelectVersion(zoneCode, env, minVersion string) (node db.WorkerNode, err error) {
+	w.nodesMtx.RLock()
+	defer w.nodesMtx.RUnlock()
+
+	selector, ok := w.selectorFor(zoneCode, env)
+	if !ok {
+		err = ErrNodesEmpty
+		return
+	}
+
+	matches := make([]db.WorkerNode, 0)
+	for _, candidate := range filterSelectable(selector.snapshot()) {
+		if versionGTE(candidate.Version, minVersion) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		err = ErrNodesEmpty
+		return
+	}
+
+	return matches[rand.Intn(len(matches))], nil
+}
+
+// Nodes returns a snapshot of every node WorkerPool currently knows about
+// for zoneCode/env, draining ones included, so a topology UI can render the
+// full picture rather than just what's selectable.
+func (w *WorkerPool) Nodes(zoneCode, env string) []NodeStatus {
+	w.nodesMtx.RLock()
+	defer w.nodesMtx.RUnlock()
+
+	selector, ok := w.selectorFor(zoneCode, env)
+	if !ok {
+		return nil
+	}
+
+	nodes := selector.snapshot()
+	out := make([]NodeStatus, 0, len(nodes))
+	for _, node := range nodes {
+		state := State(node.State)
+		if state == "" {
+			state = StateActive
+		}
+
+		out = append(out, NodeStatus{
+			HostName:  node.HostName,
+			IP:        node.IP,
+			Port:      node.Port,
+			Version:   node.Version,
+			State:     state,
+			Heartbeat: node.Heartbeat,
+		})
+	}
+
+	return out
+}
+
+// versionGTE compares dotted numeric version strings component by
+// component, e.g. versionGTE("1.2.10", "1.2.9") is true. A missing
+// component is treated as 0.
+func versionGTE(version, minVersion string) bool {
+	v := strings.Split(version, ".")
+	m := strings.Split(minVersion, ".")
+
+	for i := 0; i < len(v) || i < len(m); i++ {
+		var vPart, mPart int
+		if i < len(v) {
+			vPart, _ = strconv.Atoi(v[i])
+		}
+		if i < len(m) {
+			mPart, _ = strconv.Atoi(m[i])
+		}
+
+		if vPart != mPart {
+			return vPart > mPart
+		}
+	}
+
+	return true
+}
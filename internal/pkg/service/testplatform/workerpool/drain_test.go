@@ -0,0 +1,24 @@
+package workerpool
+
+import "testing"
+
+func TestVersionGTE(t *testing.T) {
+	cases := []struct {
+		version, minVersion string
+		want                bool
+	}{
+		{"1.2.10", "1.2.9", true},
+		{"1.2.9", "1.2.10", false},
+		{"1.2.0", "1.2.0", true},
+		{"1.3", "1.2.9", true},
+		{"1.2", "1.2.0", true},
+		{"2.0.0", "1.99.99", true},
+		{"", "1.0.0", false},
+	}
+
+	for _, c := range cases {
+		if got := versionGTE(c.version, c.minVersion); got != c.want {
+			t.Errorf("versionGTE(%q, %q) = %v, want %v", c.version, c.minVersion, got, c.want)
+		}
+	}
+}
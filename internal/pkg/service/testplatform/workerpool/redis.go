@@ -0,0 +1,99 @@
+package workerpool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/douyu/juno/pkg/model/db"
+	"github.com/douyu/jupiter/pkg/xlog"
+)
+
+const (
+	// taskListKeyFormat is the per-worker-group Redis list that Dispatch pushes
+	// onto and that workers BRPOP off of.
+	taskListKeyFormat = "juno:workerpool:tasks:%s:%s"
+
+	// heartbeatChannel is the pub/sub channel WorkerPool.Heartbeat republishes
+	// each node's heartbeat on. syncFromDB still reconciles against the DB, but liveness in w.nodes
+	// is driven by messages on this channel.
+	heartbeatChannel = "juno:workerpool:heartbeat"
+
+	// cancelChannel is the pub/sub channel job cancellations are broadcast
+	// on (see PublishCancel / scheduler.Scheduler.Cancel).
+	cancelChannel = "juno:workerpool:cancel"
+)
+
+// subscribeHeartbeat listens on heartbeatChannel and applies each incoming
+// heartbeat directly to w.nodes, so presence in the in-memory selector no
+// longer waits on the next syncFromDB tick.
+func (w *WorkerPool) subscribeHeartbeat() {
+	if w.option.Redis == nil {
+		return
+	}
+
+	ctx := context.Background()
+	sub := w.option.Redis.Subscribe(ctx, heartbeatChannel)
+
+	for msg := range sub.Channel() {
+		var node db.WorkerNode
+		if err := json.Unmarshal([]byte(msg.Payload), &node); err != nil {
+			xlog.Error("subscribeHeartbeat", xlog.String("err", err.Error()))
+			continue
+		}
+
+		w.nodesMtx.Lock()
+		w.updateNode(node)
+		w.nodesMtx.Unlock()
+	}
+}
+
+// publishHeartbeat fans the node's latest state out to heartbeatChannel so
+// every WorkerPool instance in the control plane picks it up without
+// waiting on syncFromDB.
+func (w *WorkerPool) publishHeartbeat(node db.WorkerNode) {
+	if w.option.Redis == nil {
+		return
+	}
+
+	payload, err := json.Marshal(node)
+	if err != nil {
+		xlog.Error("publishHeartbeat", xlog.String("err", err.Error()))
+		return
+	}
+
+	if err := w.option.Redis.Publish(context.Background(), heartbeatChannel, payload).Err(); err != nil {
+		xlog.Error("publishHeartbeat", xlog.String("err", err.Error()))
+	}
+}
+
+// Dispatch pushes payload onto the Redis task list for zoneCode/env (LPUSH
+// onto a plain list, not a stream). Workers in that zone/env BRPOP off the
+// same key, so callers don't need to know which specific node will pick it up.
+//
+// This is a deliberate deviation from a per-worker task list: nothing here
+// addresses a specific host, so there is no way to force a job onto one
+// named worker. The scheduler only learns which host ended up running a
+// job after the fact, from that worker's own heartbeat (see
+// scheduler.Scheduler.Heartbeat's RunningJobs). If per-worker targeting is
+// needed later, key taskListKeyFormat by host name instead of zoneCode/env.
+func (w *WorkerPool) Dispatch(zoneCode, env string, payload []byte) error {
+	if w.option.Redis == nil {
+		return ErrRedisNotConfigured
+	}
+
+	key := fmt.Sprintf(taskListKeyFormat, zoneCode, env)
+	return w.option.Redis.LPush(context.Background(), key, payload).Err()
+}
+
+// PublishCancel broadcasts jobID on cancelChannel so a worker already
+// running it can drop it once it checks in. This is best-effort pub/sub,
+// not a guaranteed delivery: a worker that isn't subscribed, or that's
+// between checks, won't see it until/unless it looks again.
+func (w *WorkerPool) PublishCancel(jobID string) error {
+	if w.option.Redis == nil {
+		return ErrRedisNotConfigured
+	}
+
+	return w.option.Redis.Publish(context.Background(), cancelChannel, []byte(jobID)).Err()
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/douyu/juno/pkg/model/db"
+	"github.com/douyu/juno/pkg/model/view"
+	"github.com/douyu/jupiter/pkg/xlog"
+)
+
+// Heartbeat is what a scheduler-aware worker reports: the usual pool
+// heartbeat plus which jobs it finished and which it's still running.
+type Heartbeat struct {
+	view.WorkerHeartbeat
+	FinishedJobs []JobID
+	RunningJobs  []JobID
+}
+
+// Heartbeat forwards params to the underlying WorkerPool and reconciles
+// in-flight job state: FinishedJobs complete their jobs (promoting
+// dependents), RunningJobs records/refreshes the job-to-worker assignment.
+func (s *Scheduler) Heartbeat(params Heartbeat) {
+	s.pool.Heartbeat(params.WorkerHeartbeat)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.lastSeen[params.HostName] = time.Now()
+
+	for _, jobID := range params.FinishedJobs {
+		record, ok := s.jobs[jobID]
+		if !ok {
+			continue
+		}
+		s.finish(record, Result{JobID: jobID}, JobDone)
+	}
+
+	if s.hostJobs[params.HostName] == nil {
+		s.hostJobs[params.HostName] = make(map[JobID]struct{})
+	}
+
+	for _, jobID := range params.RunningJobs {
+		record, ok := s.jobs[jobID]
+		if !ok || record.state == JobDone || record.state == JobCancelled {
+			continue
+		}
+
+		record.state = JobRunning
+		record.host = params.HostName
+		s.hostJobs[params.HostName][jobID] = struct{}{}
+
+		s.saveAssignment(jobID, params.HostName, record.job.ZoneCode, record.job.Env, JobRunning)
+	}
+}
+
+func (s *Scheduler) saveAssignment(jobID JobID, hostName, zoneCode, env string, state JobState) {
+	if s.db == nil {
+		return
+	}
+
+	assignment := db.JobAssignment{
+		JobID:    string(jobID),
+		HostName: hostName,
+		ZoneCode: zoneCode,
+		Env:      env,
+		State:    string(state),
+	}
+
+	err := s.db.Where("job_id = ?", string(jobID)).
+		Assign(assignment).
+		FirstOrCreate(&db.JobAssignment{}).Error
+	if err != nil {
+		xlog.Error("Scheduler.saveAssignment", xlog.String("jobID", string(jobID)), xlog.String("err", err.Error()))
+	}
+}
+
+// reapTimedOutHosts re-enqueues the running jobs of any host that hasn't
+// heartbeated within heartbeatTimeout, mirroring how
+// workerpool.clearTimeoutNodes drops the host from Select's rotation.
+func (s *Scheduler) reapTimedOutHosts() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for hostName, seenAt := range s.lastSeen {
+		if time.Since(seenAt) <= s.heartbeatTimeout {
+			continue
+		}
+
+		for jobID := range s.hostJobs[hostName] {
+			record, ok := s.jobs[jobID]
+			if !ok || record.state != JobRunning {
+				continue
+			}
+
+			record.state = JobReady
+			record.host = ""
+			if err := s.dispatch(record); err != nil {
+				s.finish(record, Result{JobID: jobID, Err: err}, JobFailed)
+			}
+		}
+
+		delete(s.hostJobs, hostName)
+		delete(s.lastSeen, hostName)
+	}
+}
@@ -0,0 +1,254 @@
+// Package scheduler turns workerpool.WorkerPool's node registry into a job
+// scheduler: callers submit a DAG of jobs, the scheduler dispatches each one
+// once its dependencies finish, and tracks which worker ends up running it
+// via that worker's own heartbeats.
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/douyu/juno/internal/pkg/service/testplatform/workerpool"
+	"github.com/douyu/jupiter/pkg/xlog"
+	"github.com/jinzhu/gorm"
+)
+
+// JobID identifies a Job across Submit, Wait and Cancel.
+type JobID string
+
+// JobState is where a Job sits in the scheduler's lifecycle.
+type JobState string
+
+const (
+	JobPending   JobState = "pending"   // waiting on Dependencies
+	JobReady     JobState = "ready"     // deps satisfied, enqueued for dispatch
+	JobRunning   JobState = "running"   // a worker has reported it in RunningJobs
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// reaperMinInterval floors how often reapTimedOutHosts runs, so a caller
+// passing heartbeatTimeout <= 0 can't turn New's background goroutine into
+// a CPU-burning busy loop.
+const reaperMinInterval = time.Second
+
+var (
+	ErrJobExists     = errors.New("scheduler: job already submitted")
+	ErrJobNotFound   = errors.New("scheduler: job not found")
+	ErrUnknownDep    = errors.New("scheduler: dependency not found")
+	ErrJobNotRunning = errors.New("scheduler: job is not running")
+)
+
+// Job is the unit of work callers submit. Dependencies must all reach
+// JobDone before Job is dispatched.
+type Job struct {
+	ID           JobID
+	ZoneCode     string
+	Env          string
+	Dependencies []JobID
+	Payload      []byte
+}
+
+// Result is what Wait returns once a job finishes. Output is only populated
+// when whatever reports the job's completion carries a real result payload;
+// the heartbeat protocol in heartbeat.go doesn't today, so jobs that finish
+// via FinishedJobs leave Output nil.
+type Result struct {
+	JobID  JobID
+	Output []byte
+	Err    error
+}
+
+type jobRecord struct {
+	job     Job
+	state   JobState
+	host    string
+	result  Result
+	doneCh  chan struct{}
+	waiting int // number of unfinished Dependencies
+}
+
+// Scheduler dispatches Jobs onto a workerpool.WorkerPool and tracks them to
+// completion via the heartbeats those workers send back.
+type Scheduler struct {
+	pool             *workerpool.WorkerPool
+	db               *gorm.DB
+	heartbeatTimeout time.Duration
+
+	mtx      sync.Mutex
+	jobs     map[JobID]*jobRecord
+	hostJobs map[string]map[JobID]struct{}
+	lastSeen map[string]time.Time
+}
+
+// New builds a Scheduler on top of pool. heartbeatTimeout should match the
+// pool's own Option.HeartbeatTimeout: it's how long a worker can go quiet
+// before its running jobs are considered lost and re-enqueued.
+func New(pool *workerpool.WorkerPool, dbConn *gorm.DB, heartbeatTimeout time.Duration) *Scheduler {
+	s := &Scheduler{
+		pool:             pool,
+		db:               dbConn,
+		heartbeatTimeout: heartbeatTimeout,
+		jobs:             make(map[JobID]*jobRecord),
+		hostJobs:         make(map[string]map[JobID]struct{}),
+		lastSeen:         make(map[string]time.Time),
+	}
+
+	interval := s.heartbeatTimeout / 2
+	if interval < reaperMinInterval {
+		interval = reaperMinInterval
+	}
+
+	go func() {
+		for {
+			time.Sleep(interval)
+			s.reapTimedOutHosts()
+		}
+	}()
+
+	return s
+}
+
+// Submit registers job. It's dispatched immediately if it has no pending
+// dependencies, otherwise it waits for them to reach JobDone.
+func (s *Scheduler) Submit(job Job) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.jobs[job.ID]; ok {
+		return ErrJobExists
+	}
+
+	waiting := 0
+	for _, depID := range job.Dependencies {
+		dep, ok := s.jobs[depID]
+		if !ok {
+			return ErrUnknownDep
+		}
+		switch dep.state {
+		case JobDone:
+			// satisfied
+		case JobFailed, JobCancelled:
+			// this dependency will never reach JobDone; fail job immediately
+			// instead of leaving it waiting forever.
+			record := &jobRecord{job: job, state: JobPending, doneCh: make(chan struct{})}
+			s.jobs[job.ID] = record
+			s.finish(record, Result{JobID: job.ID, Err: fmt.Errorf("scheduler: dependency %s did not complete", depID)}, JobFailed)
+			return nil
+		default:
+			waiting++
+		}
+	}
+
+	record := &jobRecord{job: job, state: JobPending, doneCh: make(chan struct{}), waiting: waiting}
+	s.jobs[job.ID] = record
+
+	if waiting == 0 {
+		return s.dispatch(record)
+	}
+
+	return nil
+}
+
+// dispatch pushes job onto its zone/env's Redis task list. Callers must
+// hold s.mtx.
+func (s *Scheduler) dispatch(record *jobRecord) error {
+	payload, err := json.Marshal(record.job)
+	if err != nil {
+		return err
+	}
+
+	record.state = JobReady
+	if err := s.pool.Dispatch(record.job.ZoneCode, record.job.Env, payload); err != nil {
+		xlog.Error("Scheduler.dispatch", xlog.String("jobID", string(record.job.ID)), xlog.String("err", err.Error()))
+		return err
+	}
+
+	return nil
+}
+
+// Wait blocks until jobID reaches a terminal state and returns its Result.
+func (s *Scheduler) Wait(jobID JobID) (Result, error) {
+	s.mtx.Lock()
+	record, ok := s.jobs[jobID]
+	s.mtx.Unlock()
+	if !ok {
+		return Result{}, ErrJobNotFound
+	}
+
+	<-record.doneCh
+	return record.result, nil
+}
+
+// Cancel marks jobID cancelled, wakes any Wait callers, and broadcasts the
+// cancellation on the Redis cancel channel via WorkerPool.PublishCancel so a
+// worker already running the job can drop it once it checks in. The
+// broadcast is best-effort: if Redis isn't configured, or the worker never
+// looks again, cancellation stays local-only and the job keeps running.
+func (s *Scheduler) Cancel(jobID JobID) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	record, ok := s.jobs[jobID]
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if err := s.pool.PublishCancel(string(jobID)); err != nil {
+		xlog.Error("Scheduler.Cancel", xlog.String("jobID", string(jobID)), xlog.String("err", err.Error()))
+	}
+
+	s.finish(record, Result{JobID: jobID, Err: errors.New("scheduler: job cancelled")}, JobCancelled)
+	return nil
+}
+
+// finish transitions record to a terminal state, records it, and resolves
+// any dependents: JobDone promotes a dependent once its last blocking
+// dependency clears, while JobFailed/JobCancelled propagates the same
+// terminal state to every dependent instead of leaving it waiting forever.
+// Callers must hold s.mtx.
+func (s *Scheduler) finish(record *jobRecord, result Result, state JobState) {
+	if record.state == JobDone || record.state == JobFailed || record.state == JobCancelled {
+		return
+	}
+
+	record.state = state
+	record.result = result
+	close(record.doneCh)
+
+	if host := record.host; host != "" {
+		delete(s.hostJobs[host], record.job.ID)
+	}
+
+	for _, other := range s.jobs {
+		if other.state != JobPending {
+			continue
+		}
+
+		dependsOnRecord := false
+		for _, depID := range other.job.Dependencies {
+			if depID == record.job.ID {
+				dependsOnRecord = true
+				other.waiting--
+			}
+		}
+		if !dependsOnRecord {
+			continue
+		}
+
+		if state != JobDone {
+			s.finish(other, Result{JobID: other.job.ID, Err: fmt.Errorf("scheduler: dependency %s did not complete", record.job.ID)}, state)
+			continue
+		}
+
+		if other.waiting == 0 {
+			if err := s.dispatch(other); err != nil {
+				s.finish(other, Result{JobID: other.job.ID, Err: err}, JobFailed)
+			}
+		}
+	}
+}
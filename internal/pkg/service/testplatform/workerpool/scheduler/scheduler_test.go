@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/douyu/juno/internal/pkg/service/testplatform/workerpool"
+)
+
+// newTestScheduler builds a Scheduler with no background goroutines and no
+// Redis-backed WorkerPool, so dispatch() always returns
+// workerpool.ErrRedisNotConfigured. That's enough to exercise the
+// dependency-promotion and timeout-reenqueue bookkeeping this test file
+// covers: what matters is that those paths run (and fail closed) rather
+// than leaving a job wedged in JobPending forever.
+func newTestScheduler() *Scheduler {
+	return &Scheduler{
+		pool:             new(workerpool.WorkerPool),
+		heartbeatTimeout: time.Minute,
+		jobs:             make(map[JobID]*jobRecord),
+		hostJobs:         make(map[string]map[JobID]struct{}),
+		lastSeen:         make(map[string]time.Time),
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFinishPromotesDependentWhenDependencyDone(t *testing.T) {
+	s := newTestScheduler()
+
+	parent := &jobRecord{job: Job{ID: "a"}, state: JobRunning, doneCh: make(chan struct{})}
+	child := &jobRecord{job: Job{ID: "b", Dependencies: []JobID{"a"}}, state: JobPending, doneCh: make(chan struct{}), waiting: 1}
+	s.jobs["a"] = parent
+	s.jobs["b"] = child
+
+	s.finish(parent, Result{JobID: "a"}, JobDone)
+
+	if parent.state != JobDone {
+		t.Fatalf("expected parent to reach JobDone, got %v", parent.state)
+	}
+	if child.waiting != 0 {
+		t.Fatalf("expected child's waiting counter to reach 0, got %d", child.waiting)
+	}
+	// the zero-value pool has no Redis configured, so the promotion's
+	// dispatch attempt fails and the child is failed rather than left ready.
+	if child.state != JobFailed {
+		t.Fatalf("expected child to be dispatched (and fail closed, no Redis configured), got %v", child.state)
+	}
+	if !isClosed(child.doneCh) {
+		t.Fatal("expected child.doneCh to be closed once it reached a terminal state")
+	}
+}
+
+func TestFinishPropagatesFailureToDependents(t *testing.T) {
+	s := newTestScheduler()
+
+	parent := &jobRecord{job: Job{ID: "a"}, state: JobRunning, doneCh: make(chan struct{})}
+	child := &jobRecord{job: Job{ID: "b", Dependencies: []JobID{"a"}}, state: JobPending, doneCh: make(chan struct{}), waiting: 1}
+	s.jobs["a"] = parent
+	s.jobs["b"] = child
+
+	s.finish(parent, Result{JobID: "a", Err: workerpool.ErrNodesEmpty}, JobFailed)
+
+	if child.state != JobFailed {
+		t.Fatalf("expected a failed dependency to fail its dependent, got %v", child.state)
+	}
+	if !isClosed(child.doneCh) {
+		t.Fatal("expected child.doneCh to be closed so Wait(b) doesn't block forever")
+	}
+	if child.result.Err == nil {
+		t.Fatal("expected child's Result to carry an error explaining why it was failed")
+	}
+}
+
+func TestFinishPropagatesCancellationToDependents(t *testing.T) {
+	s := newTestScheduler()
+
+	parent := &jobRecord{job: Job{ID: "a"}, state: JobPending, doneCh: make(chan struct{})}
+	child := &jobRecord{job: Job{ID: "b", Dependencies: []JobID{"a"}}, state: JobPending, doneCh: make(chan struct{}), waiting: 1}
+	s.jobs["a"] = parent
+	s.jobs["b"] = child
+
+	if err := s.Cancel("a"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+
+	if child.state != JobCancelled {
+		t.Fatalf("expected cancelling a dependency to cancel its dependent, got %v", child.state)
+	}
+	if !isClosed(child.doneCh) {
+		t.Fatal("expected child.doneCh to be closed after cancellation propagates")
+	}
+}
+
+func TestSubmitFailsImmediatelyWhenDependencyAlreadyFailed(t *testing.T) {
+	s := newTestScheduler()
+
+	failedDep := &jobRecord{job: Job{ID: "a"}, state: JobFailed, doneCh: make(chan struct{})}
+	close(failedDep.doneCh)
+	s.jobs["a"] = failedDep
+
+	err := s.Submit(Job{ID: "b", Dependencies: []JobID{"a"}})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	child, ok := s.jobs["b"]
+	if !ok {
+		t.Fatal("expected job b to be registered")
+	}
+	if child.state != JobFailed {
+		t.Fatalf("expected submitting a job depending on an already-failed job to fail it immediately, got %v", child.state)
+	}
+	if !isClosed(child.doneCh) {
+		t.Fatal("expected child.doneCh to be closed so Wait(b) returns instead of blocking forever")
+	}
+}
+
+func TestReapTimedOutHostsReenqueuesRunningJobs(t *testing.T) {
+	s := newTestScheduler()
+
+	record := &jobRecord{job: Job{ID: "a"}, state: JobRunning, host: "h1", doneCh: make(chan struct{})}
+	s.jobs["a"] = record
+	s.hostJobs["h1"] = map[JobID]struct{}{"a": {}}
+	s.lastSeen["h1"] = time.Now().Add(-2 * s.heartbeatTimeout)
+
+	s.reapTimedOutHosts()
+
+	if record.host != "" {
+		t.Fatalf("expected the job's host assignment to be cleared, got %q", record.host)
+	}
+	// the zero-value pool has no Redis configured, so the re-dispatch attempt
+	// fails and the job is failed rather than left running against a dead host.
+	if record.state != JobFailed {
+		t.Fatalf("expected the re-enqueue attempt to run (and fail closed, no Redis configured), got %v", record.state)
+	}
+	if _, ok := s.hostJobs["h1"]; ok {
+		t.Fatal("expected the timed-out host to be removed from hostJobs")
+	}
+	if _, ok := s.lastSeen["h1"]; ok {
+		t.Fatal("expected the timed-out host to be removed from lastSeen")
+	}
+}
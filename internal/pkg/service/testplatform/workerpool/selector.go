@@ -0,0 +1,350 @@
+package workerpool
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/douyu/juno/pkg/model/db"
+)
+
+// Strategy picks which Selector implementation a zone/env bucket uses.
+type Strategy string
+
+const (
+	StrategyRoundRobin     Strategy = "round_robin"
+	StrategyWeightedRandom Strategy = "weighted_random"
+	StrategyLeastLoaded    Strategy = "least_loaded"
+	StrategyRendezvous     Strategy = "rendezvous"
+)
+
+// Selector tracks the live nodes for a single zone/env bucket and decides
+// which one Select() hands back next. Each strategy keeps its own node set
+// so pick() can stay lock-free of the others.
+type Selector interface {
+	push(node db.WorkerNode)
+	pick() (db.WorkerNode, error)
+	get(hostName string) (db.WorkerNode, bool)
+	snapshot() []db.WorkerNode
+	clearTimeoutNodes(timeout time.Duration)
+}
+
+// isSelectable reports whether pick() may hand node out. A draining or
+// stopping node is only reachable by hostName through get()
+// (WorkerPool.Drain, WorkerPool.Heartbeat) or in the full listing of
+// WorkerPool.Nodes.
+func isSelectable(node db.WorkerNode) bool {
+	return node.State == "" || node.State == string(StateActive)
+}
+
+func filterSelectable(nodes []db.WorkerNode) []db.WorkerNode {
+	out := nodes[:0]
+	for _, node := range nodes {
+		if isSelectable(node) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+func getNode(mtx *sync.Mutex, nodes map[string]db.WorkerNode, hostName string) (db.WorkerNode, bool) {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	node, ok := nodes[hostName]
+	return node, ok
+}
+
+func makeSelector(strategy Strategy) Selector {
+	switch strategy {
+	case StrategyWeightedRandom:
+		return &weightedRandomSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+	case StrategyLeastLoaded:
+		return &leastLoadedSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+	case StrategyRendezvous:
+		return &rendezvousSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+	default:
+		return &roundRobinSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+	}
+}
+
+func clearTimeoutNodes(mtx *sync.Mutex, nodes map[string]db.WorkerNode, lastSeen map[string]time.Time, timeout time.Duration) {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	for hostName, seenAt := range lastSeen {
+		if time.Since(seenAt) > timeout {
+			delete(nodes, hostName)
+			delete(lastSeen, hostName)
+		}
+	}
+}
+
+func snapshotNodes(mtx *sync.Mutex, nodes map[string]db.WorkerNode) []db.WorkerNode {
+	mtx.Lock()
+	defer mtx.Unlock()
+
+	out := make([]db.WorkerNode, 0, len(nodes))
+	for _, node := range nodes {
+		out = append(out, node)
+	}
+	return out
+}
+
+// roundRobinSelector is the original behavior: cycle through nodes in the
+// order they were first seen.
+type roundRobinSelector struct {
+	mtx      sync.Mutex
+	nodes    map[string]db.WorkerNode
+	lastSeen map[string]time.Time
+	order    []string
+	idx      int
+}
+
+func (s *roundRobinSelector) push(node db.WorkerNode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if _, ok := s.nodes[node.HostName]; !ok {
+		s.order = append(s.order, node.HostName)
+	}
+	s.nodes[node.HostName] = node
+	s.lastSeen[node.HostName] = time.Now()
+}
+
+func (s *roundRobinSelector) pick() (db.WorkerNode, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// drop hostnames that timed out since they were added to order.
+	kept := s.order[:0]
+	for _, hostName := range s.order {
+		if _, ok := s.nodes[hostName]; ok {
+			kept = append(kept, hostName)
+		}
+	}
+	s.order = kept
+
+	for i := 0; i < len(s.order); i++ {
+		hostName := s.order[s.idx%len(s.order)]
+		s.idx++
+
+		if node, ok := s.nodes[hostName]; ok && isSelectable(node) {
+			return node, nil
+		}
+	}
+
+	return db.WorkerNode{}, ErrNodesEmpty
+}
+
+func (s *roundRobinSelector) get(hostName string) (db.WorkerNode, bool) {
+	return getNode(&s.mtx, s.nodes, hostName)
+}
+
+func (s *roundRobinSelector) snapshot() []db.WorkerNode {
+	return snapshotNodes(&s.mtx, s.nodes)
+}
+
+func (s *roundRobinSelector) clearTimeoutNodes(timeout time.Duration) {
+	clearTimeoutNodes(&s.mtx, s.nodes, s.lastSeen, timeout)
+}
+
+// weightedRandomSelector favors nodes reporting fewer inflight jobs, without
+// starving the busier ones outright.
+type weightedRandomSelector struct {
+	mtx      sync.Mutex
+	nodes    map[string]db.WorkerNode
+	lastSeen map[string]time.Time
+}
+
+func (s *weightedRandomSelector) push(node db.WorkerNode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.nodes[node.HostName] = node
+	s.lastSeen[node.HostName] = time.Now()
+}
+
+func (s *weightedRandomSelector) get(hostName string) (db.WorkerNode, bool) {
+	return getNode(&s.mtx, s.nodes, hostName)
+}
+
+func (s *weightedRandomSelector) pick() (db.WorkerNode, error) {
+	nodes := filterSelectable(s.snapshot())
+	if len(nodes) == 0 {
+		return db.WorkerNode{}, ErrNodesEmpty
+	}
+
+	weights := make([]float64, len(nodes))
+	total := 0.0
+	for i, node := range nodes {
+		weights[i] = 1 / (1 + float64(node.Heartbeat.InflightJobs))
+		total += weights[i]
+	}
+
+	r := rand.Float64() * total
+	for i, w := range weights {
+		r -= w
+		if r <= 0 {
+			return nodes[i], nil
+		}
+	}
+
+	return nodes[len(nodes)-1], nil
+}
+
+func (s *weightedRandomSelector) snapshot() []db.WorkerNode {
+	return snapshotNodes(&s.mtx, s.nodes)
+}
+
+func (s *weightedRandomSelector) clearTimeoutNodes(timeout time.Duration) {
+	clearTimeoutNodes(&s.mtx, s.nodes, s.lastSeen, timeout)
+}
+
+// leastLoadedSelector ranks nodes by reported inflight jobs, breaking ties
+// by CPU and then memory percent.
+type leastLoadedSelector struct {
+	mtx      sync.Mutex
+	nodes    map[string]db.WorkerNode
+	lastSeen map[string]time.Time
+}
+
+func (s *leastLoadedSelector) push(node db.WorkerNode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.nodes[node.HostName] = node
+	s.lastSeen[node.HostName] = time.Now()
+}
+
+func (s *leastLoadedSelector) get(hostName string) (db.WorkerNode, bool) {
+	return getNode(&s.mtx, s.nodes, hostName)
+}
+
+func (s *leastLoadedSelector) pick() (db.WorkerNode, error) {
+	nodes := filterSelectable(s.snapshot())
+	if len(nodes) == 0 {
+		return db.WorkerNode{}, ErrNodesEmpty
+	}
+
+	best := nodes[0]
+	for _, node := range nodes[1:] {
+		if node.Heartbeat.InflightJobs != best.Heartbeat.InflightJobs {
+			if node.Heartbeat.InflightJobs < best.Heartbeat.InflightJobs {
+				best = node
+			}
+			continue
+		}
+		if node.Heartbeat.CPU != best.Heartbeat.CPU {
+			if node.Heartbeat.CPU < best.Heartbeat.CPU {
+				best = node
+			}
+			continue
+		}
+		if node.Heartbeat.MemPercent < best.Heartbeat.MemPercent {
+			best = node
+		}
+	}
+
+	return best, nil
+}
+
+func (s *leastLoadedSelector) snapshot() []db.WorkerNode {
+	return snapshotNodes(&s.mtx, s.nodes)
+}
+
+func (s *leastLoadedSelector) clearTimeoutNodes(timeout time.Duration) {
+	clearTimeoutNodes(&s.mtx, s.nodes, s.lastSeen, timeout)
+}
+
+// rendezvousSelector backs WorkerPool.SelectByKey: the same hashKey always
+// maps to the same node as long as it's alive (highest-random-weight
+// hashing). pick(), used by the plain Select() path, just hashes the zone's
+// node set with an empty key since there's no caller-supplied key there -
+// that key is the same for every call, so it deterministically picks the
+// same single node every time. StrategyRendezvous only makes sense as
+// Option.SelectStrategy if every caller in that zone/env uses SelectByKey;
+// plain Select() callers should pick one of the other strategies, which
+// spread load across the live nodes.
+type rendezvousSelector struct {
+	mtx      sync.Mutex
+	nodes    map[string]db.WorkerNode
+	lastSeen map[string]time.Time
+}
+
+func (s *rendezvousSelector) push(node db.WorkerNode) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.nodes[node.HostName] = node
+	s.lastSeen[node.HostName] = time.Now()
+}
+
+func (s *rendezvousSelector) get(hostName string) (db.WorkerNode, bool) {
+	return getNode(&s.mtx, s.nodes, hostName)
+}
+
+func (s *rendezvousSelector) pick() (db.WorkerNode, error) {
+	return pickByKey(filterSelectable(s.snapshot()), "")
+}
+
+func (s *rendezvousSelector) snapshot() []db.WorkerNode {
+	return snapshotNodes(&s.mtx, s.nodes)
+}
+
+func (s *rendezvousSelector) clearTimeoutNodes(timeout time.Duration) {
+	clearTimeoutNodes(&s.mtx, s.nodes, s.lastSeen, timeout)
+}
+
+// pickByKey runs rendezvous (highest random weight) hashing over nodes and
+// returns the one that scores highest for hashKey. It's also what
+// WorkerPool.SelectByKey uses directly, regardless of the zone's configured
+// Strategy, since sticky routing is a property of the call, not the pool.
+func pickByKey(nodes []db.WorkerNode, hashKey string) (db.WorkerNode, error) {
+	if len(nodes) == 0 {
+		return db.WorkerNode{}, ErrNodesEmpty
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].HostName < nodes[j].HostName })
+
+	var best db.WorkerNode
+	bestScore := -1.0
+	for _, node := range nodes {
+		score := rendezvousWeight(node.HostName, hashKey)
+		if score > bestScore {
+			best = node
+			bestScore = score
+		}
+	}
+
+	return best, nil
+}
+
+func rendezvousWeight(hostName, hashKey string) float64 {
+	h := fnv32(hostName + "|" + hashKey)
+	// FNV alone lets the last bytes of hashKey touch only the low bits, so
+	// keys differing in a suffix ("job-1", "job-2") would all rank the nodes
+	// the same way; mix the result (murmur3 fmix32) before scoring.
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return math.Log(float64(h)+1) / float64(math.MaxUint32)
+}
+
+func fnv32(s string) uint32 {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	h := uint32(offset32)
+	for i := 0; i < len(s); i++ {
+		h *= prime32
+		h ^= uint32(s[i])
+	}
+	return h
+}
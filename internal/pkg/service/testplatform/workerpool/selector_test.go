@@ -0,0 +1,117 @@
+package workerpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/douyu/juno/pkg/model/db"
+)
+
+func TestPickByKeyIsDeterministic(t *testing.T) {
+	nodes := []db.WorkerNode{
+		{HostName: "a"},
+		{HostName: "b"},
+		{HostName: "c"},
+	}
+
+	first, err := pickByKey(nodes, "job-42")
+	if err != nil {
+		t.Fatalf("pickByKey: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		got, err := pickByKey(nodes, "job-42")
+		if err != nil {
+			t.Fatalf("pickByKey: %v", err)
+		}
+		if got.HostName != first.HostName {
+			t.Fatalf("pickByKey(%q) returned %q, then %q on a later call", "job-42", first.HostName, got.HostName)
+		}
+	}
+}
+
+func TestPickByKeySpreadsAcrossKeys(t *testing.T) {
+	nodes := []db.WorkerNode{
+		{HostName: "a"},
+		{HostName: "b"},
+		{HostName: "c"},
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range []string{"job-1", "job-2", "job-3", "job-4", "job-5"} {
+		node, err := pickByKey(nodes, key)
+		if err != nil {
+			t.Fatalf("pickByKey: %v", err)
+		}
+		seen[node.HostName] = true
+	}
+
+	if len(seen) < 2 {
+		t.Fatalf("expected different keys to land on more than one node, all landed on %v", seen)
+	}
+}
+
+func TestLeastLoadedSelectorRanksByInflightThenCPUThenMem(t *testing.T) {
+	s := &leastLoadedSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+
+	s.push(db.WorkerNode{HostName: "busy", Heartbeat: db.WorkerLoad{InflightJobs: 5}})
+	s.push(db.WorkerNode{HostName: "idle", Heartbeat: db.WorkerLoad{InflightJobs: 1, CPU: 50}})
+	s.push(db.WorkerNode{HostName: "tied-high-cpu", Heartbeat: db.WorkerLoad{InflightJobs: 1, CPU: 90}})
+	s.push(db.WorkerNode{HostName: "tied-low-cpu-high-mem", Heartbeat: db.WorkerLoad{InflightJobs: 1, CPU: 10, MemPercent: 90}})
+	s.push(db.WorkerNode{HostName: "tied-low-cpu-low-mem", Heartbeat: db.WorkerLoad{InflightJobs: 1, CPU: 10, MemPercent: 10}})
+
+	node, err := s.pick()
+	if err != nil {
+		t.Fatalf("pick: %v", err)
+	}
+	if node.HostName != "tied-low-cpu-low-mem" {
+		t.Fatalf("expected the least-loaded node to win on inflight, then CPU, then mem; got %q", node.HostName)
+	}
+}
+
+func TestWeightedRandomSelectorFavorsLowerInflight(t *testing.T) {
+	s := &weightedRandomSelector{nodes: make(map[string]db.WorkerNode), lastSeen: make(map[string]time.Time)}
+	s.push(db.WorkerNode{HostName: "idle", Heartbeat: db.WorkerLoad{InflightJobs: 0}})
+	s.push(db.WorkerNode{HostName: "busy", Heartbeat: db.WorkerLoad{InflightJobs: 999}})
+
+	idleWins := 0
+	const trials = 200
+	for i := 0; i < trials; i++ {
+		node, err := s.pick()
+		if err != nil {
+			t.Fatalf("pick: %v", err)
+		}
+		if node.HostName == "idle" {
+			idleWins++
+		}
+	}
+
+	if idleWins < trials*9/10 {
+		t.Fatalf("expected the near-idle node to win the large majority of picks, got %d/%d", idleWins, trials)
+	}
+}
+
+func TestSelectorsSkipDrainingNodes(t *testing.T) {
+	active := db.WorkerNode{HostName: "active"}
+	draining := db.WorkerNode{HostName: "draining", State: string(StateDraining)}
+
+	for _, strategy := range []Strategy{StrategyRoundRobin, StrategyWeightedRandom, StrategyLeastLoaded} {
+		sel := makeSelector(strategy)
+		sel.push(draining)
+		sel.push(active)
+
+		for i := 0; i < 10; i++ {
+			node, err := sel.pick()
+			if err != nil {
+				t.Fatalf("%s: pick: %v", strategy, err)
+			}
+			if node.HostName != "active" {
+				t.Fatalf("%s: pick() returned draining node %q", strategy, node.HostName)
+			}
+		}
+
+		if got, ok := sel.get("draining"); !ok || got.HostName != "draining" {
+			t.Fatalf("%s: get() should still return a draining node by hostname", strategy)
+		}
+	}
+}
@@ -2,40 +2,67 @@ package workerpool
 
 import (
 	"errors"
+	"math/rand"
 	"sync"
 	"time"
 
 	"github.com/douyu/juno/pkg/model/db"
 	"github.com/douyu/juno/pkg/model/view"
+	"github.com/douyu/jupiter/pkg/client/redis"
 	"github.com/douyu/jupiter/pkg/xlog"
 	"github.com/jinzhu/gorm"
 )
 
 type (
 	WorkerPool struct {
-		option   Option
-		nodesMtx sync.RWMutex
-		nodes    map[string]map[string]*workerSelector
+		option      Option
+		nodesMtx    sync.RWMutex
+		nodes       map[string]map[string]Selector
+		heartbeatCh chan db.WorkerNode
 	}
 
 	Option struct {
 		DB               *gorm.DB
 		HeartbeatTimeout time.Duration
+
+		// Redis is optional. When set, heartbeats and Dispatch use it so that
+		// liveness detection and task handoff don't depend on syncFromDB's
+		// 10-second poll of db.WorkerNode.
+		Redis *redis.Client
+
+		// SelectStrategy controls how Select() picks among the live nodes in
+		// a zone/env bucket. Defaults to StrategyRoundRobin.
+		SelectStrategy Strategy
+
+		// HeartbeatFlushInterval and HeartbeatBatchSize control the batched
+		// DB writer: incoming heartbeats are flushed as a single upsert every
+		// HeartbeatFlushInterval, or as soon as HeartbeatBatchSize accumulate,
+		// whichever comes first. Default 200ms / 100 entries.
+		HeartbeatFlushInterval time.Duration
+		HeartbeatBatchSize     int
 	}
 )
 
+const (
+	defaultHeartbeatFlushInterval = 200 * time.Millisecond
+	defaultHeartbeatBatchSize     = 100
+	heartbeatChanBuffer           = 1024
+)
+
 var (
 	instance *WorkerPool
 	initOnce sync.Once
 
-	ErrNodesEmpty = errors.New("worker nodes empty in current env")
+	ErrNodesEmpty         = errors.New("worker nodes empty in current env")
+	ErrRedisNotConfigured = errors.New("workerpool: redis not configured")
 )
 
 func Instance() *WorkerPool {
 	initOnce.Do(func() {
 		instance = new(WorkerPool)
 		instance.nodesMtx = sync.RWMutex{}
-		instance.nodes = make(map[string]map[string]*workerSelector)
+		instance.nodes = make(map[string]map[string]Selector)
+		instance.heartbeatCh = make(chan db.WorkerNode, heartbeatChanBuffer)
 	})
 
 	return instance
@@ -44,6 +71,9 @@ func Instance() *WorkerPool {
 func (w *WorkerPool) Init(option Option) {
 	w.option = option
 
+	// syncFromDB is now an eventual-consistency reconciler: with Redis
+	// configured, subscribeHeartbeat keeps w.nodes fresh in near real time,
+	// and this loop only catches nodes whose pub/sub heartbeat was missed.
 	go func() {
 		for {
 			w.syncFromDB()
@@ -57,6 +87,12 @@ func (w *WorkerPool) Init(option Option) {
 			time.Sleep(1 * time.Second)
 		}
 	}()
+
+	if w.option.Redis != nil {
+		go w.subscribeHeartbeat()
+	}
+
+	go w.flushHeartbeats()
 }
 
 func (w *WorkerPool) clearTimeoutNodes() {
@@ -86,12 +122,12 @@ func (w *WorkerPool) syncFromDB() {
 	for _, worker := range nodes {
 		envNodeMap := w.nodes[worker.ZoneCode]
 		if envNodeMap == nil {
-			envNodeMap = make(map[string]*workerSelector)
+			envNodeMap = make(map[string]Selector)
 		}
 
 		selector, ok := envNodeMap[worker.Env]
 		if !ok {
-			selector = makeSelector()
+			selector = makeSelector(w.option.SelectStrategy)
 		}
 
 		selector.push(worker)
@@ -101,62 +137,68 @@ func (w *WorkerPool) syncFromDB() {
 	}
 }
 
+// Heartbeat records a worker's liveness. w.nodes is updated immediately so
+// Select sees the worker right away; the DB row is written by the batched
+// writer goroutine (see batch.go) instead of a per-call transaction, since
+// workers typically heartbeat every 1-5s and a transaction per heartbeat
+// doesn't scale to hundreds of workers.
 func (w *WorkerPool) Heartbeat(params view.WorkerHeartbeat) {
-	var node db.WorkerNode
+	node := db.WorkerNode{
+		HostName:   params.HostName,
+		RegionCode: params.RegionCode,
+		RegionName: params.RegionName,
+		ZoneCode:   params.ZoneCode,
+		ZoneName:   params.ZoneName,
+		Env:        params.Env,
+		IP:         params.IP,
+		Port:       params.Port,
+		Version:    params.Version,
+		Labels:     db.StringMap(params.Labels),
+		Heartbeat: db.WorkerLoad{
+			InflightJobs: params.InflightJobs,
+			CPU:          params.CPU,
+			MemPercent:   params.MemPercent,
+		},
+		LastHeartbeat: time.Now(),
+	}
 
 	w.nodesMtx.Lock()
-	defer w.nodesMtx.Unlock()
-	tx := w.option.DB.Begin()
-	{
-		err := tx.Where("host_name = ? and zone_code = ? and env = ? and region_code = ?",
-			params.HostName, params.ZoneCode, params.Env, params.RegionCode).First(&node).Error
-		if err != nil {
-			if err == gorm.ErrRecordNotFound {
-				node = db.WorkerNode{
-					HostName:   params.HostName,
-					RegionCode: params.RegionCode,
-					ZoneCode:   params.ZoneCode,
-					Env:        params.Env,
-				}
-			} else {
-				xlog.Error("WorkerPool.Heartbeat", xlog.String("err", err.Error()))
-				return
+	// State is operator-controlled via Drain, not reported by the worker, so
+	// it must survive the node this heartbeat otherwise replaces. Labels are
+	// worker-reported but rarely change between heartbeats, so a heartbeat
+	// that omits them (or predates this field) must not wipe out labels a
+	// previous heartbeat already established.
+	if existing, ok := w.selectorFor(node.ZoneCode, node.Env); ok {
+		if prev, ok := existing.get(node.HostName); ok {
+			node.State = prev.State
+			if len(node.Labels) == 0 {
+				node.Labels = prev.Labels
 			}
 		}
+	}
+	w.updateNode(node)
+	w.nodesMtx.Unlock()
 
-		node.LastHeartbeat = time.Now()
-
-		node.RegionName = params.RegionName
-		node.ZoneName = params.ZoneName
-		node.IP = params.IP
-		node.Port = params.Port
-
-		err = tx.Save(&node).Error
-		if err != nil {
-			xlog.Error("WorkerPool.Heartbeat save node failed", xlog.String("err", err.Error()))
-			tx.Rollback()
-			return
-		}
+	w.publishHeartbeat(node)
 
-		w.updateNode(node)
-	}
-	tx.Commit()
+	w.heartbeatCh <- node
 }
 
 //updateNode NOT SAFE, MUST lock w.nodesMtx before call this function
 func (w *WorkerPool) updateNode(node db.WorkerNode) {
 	envNodeMap, ok := w.nodes[node.ZoneCode]
 	if !ok {
-		envNodeMap = make(map[string]*workerSelector)
+		envNodeMap = make(map[string]Selector)
 	}
 
 	selector, ok := envNodeMap[node.Env]
 	if !ok {
-		selector = makeSelector()
+		selector = makeSelector(w.option.SelectStrategy)
 	}
 
 	selector.push(node)
 
+	envNodeMap[node.Env] = selector
 	w.nodes[node.ZoneCode] = envNodeMap
 }
 
@@ -183,3 +225,57 @@ func (w *WorkerPool) Select(zoneCode, env string) (node db.WorkerNode, err error
 
 	return
 }
+
+// SelectByKey routes related jobs to the same worker as long as it's alive:
+// the same hashKey always resolves to the same node via rendezvous hashing,
+// regardless of the zone's configured Strategy.
+func (w *WorkerPool) SelectByKey(zoneCode, env, hashKey string) (node db.WorkerNode, err error) {
+	w.nodesMtx.RLock()
+	defer w.nodesMtx.RUnlock()
+
+	selector, ok := w.selectorFor(zoneCode, env)
+	if !ok {
+		err = ErrNodesEmpty
+		return
+	}
+
+	return pickByKey(filterSelectable(selector.snapshot()), hashKey)
+}
+
+// SelectWithConstraints picks among the nodes in zoneCode/env whose Labels
+// are a superset of labels, choosing uniformly at random among the matches;
+// the zone's configured Strategy is not applied here.
+func (w *WorkerPool) SelectWithConstraints(zoneCode, env string, labels map[string]string) (node db.WorkerNode, err error) {
+	w.nodesMtx.RLock()
+	defer w.nodesMtx.RUnlock()
+
+	selector, ok := w.selectorFor(zoneCode, env)
+	if !ok {
+		err = ErrNodesEmpty
+		return
+	}
+
+	matches := make([]db.WorkerNode, 0)
+	for _, candidate := range filterSelectable(selector.snapshot()) {
+		if candidate.HasLabels(labels) {
+			matches = append(matches, candidate)
+		}
+	}
+
+	if len(matches) == 0 {
+		err = ErrNodesEmpty
+		return
+	}
+
+	return matches[rand.Intn(len(matches))], nil
+}
+
+func (w *WorkerPool) selectorFor(zoneCode, env string) (Selector, bool) {
+	envNodeMap := w.nodes[zoneCode]
+	if envNodeMap == nil {
+		return nil, false
+	}
+
+	selector, ok := envNodeMap[env]
+	return selector, ok
+}
@@ -0,0 +1,23 @@
+package db
+
+import "time"
+
+// JobAssignment persists which worker a scheduler.Job is (or was) running
+// on, so an operator can see in-flight work and the scheduler can recover
+// after a restart.
+type JobAssignment struct {
+	ID uint `gorm:"primary_key" json:"id"`
+
+	JobID    string `gorm:"column:job_id" json:"jobId"`
+	HostName string `gorm:"column:host_name" json:"hostName"`
+	ZoneCode string `gorm:"column:zone_code" json:"zoneCode"`
+	Env      string `gorm:"column:env" json:"env"`
+	State    string `gorm:"column:state" json:"state"`
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+func (JobAssignment) TableName() string {
+	return "job_assignment"
+}
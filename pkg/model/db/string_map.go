@@ -0,0 +1,43 @@
+package db
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// StringMap stores a map[string]string as a JSON text column, for model
+// fields like WorkerNode.Labels where the key set isn't known up front.
+type StringMap map[string]string
+
+func (m StringMap) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+func (m *StringMap) Scan(src interface{}) error {
+	if src == nil {
+		*m = StringMap{}
+		return nil
+	}
+
+	var raw []byte
+	switch v := src.(type) {
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return errors.New("db: StringMap.Scan: unsupported source type")
+	}
+
+	if len(raw) == 0 {
+		*m = StringMap{}
+		return nil
+	}
+
+	return json.Unmarshal(raw, m)
+}
@@ -0,0 +1,64 @@
+package db
+
+import "time"
+
+// WorkerNode is the persisted record of a testplatform worker, keyed by
+// host/zone/env/region. workerpool.WorkerPool reconciles its in-memory
+// registry against this table.
+type WorkerNode struct {
+	ID uint `gorm:"primary_key" json:"id"`
+
+	// HostName+ZoneCode+Env+RegionCode is the node's logical identity: the
+	// batched heartbeat writer (see workerpool/batch.go) upserts on this
+	// composite key, so it must stay a unique index.
+	HostName   string `gorm:"column:host_name;unique_index:idx_worker_node_identity" json:"hostName"`
+	RegionCode string `gorm:"column:region_code;unique_index:idx_worker_node_identity" json:"regionCode"`
+	RegionName string `gorm:"column:region_name" json:"regionName"`
+	ZoneCode   string `gorm:"column:zone_code;unique_index:idx_worker_node_identity" json:"zoneCode"`
+	ZoneName   string `gorm:"column:zone_name" json:"zoneName"`
+	Env        string `gorm:"column:env;unique_index:idx_worker_node_identity" json:"env"`
+	IP         string `gorm:"column:ip" json:"ip"`
+	Port       int    `gorm:"column:port" json:"port"`
+
+	// Labels are free-form tags the worker reports in its heartbeat (e.g.
+	// "arch=arm64", "pool=canary") used by WorkerPool.SelectWithConstraints to filter
+	// candidates. Stored as JSON since label sets vary per deployment.
+	Labels StringMap `gorm:"column:labels;type:text" json:"labels"`
+
+	// Heartbeat carries the load the node last reported, so least-loaded and
+	// weighted-random selection can rank live candidates.
+	Heartbeat WorkerLoad `gorm:"embedded" json:"heartbeat"`
+
+	// Version is the worker's own build version, self-reported on every
+	// heartbeat, used by WorkerPool.SelectVersion for canary/rolling-upgrade
+	// routing.
+	Version string `gorm:"column:version" json:"version"`
+
+	// State is operator-controlled (see WorkerPool.Drain), not reported by
+	// the worker itself, so it must survive across heartbeats. Empty means
+	// active.
+	State string `gorm:"column:state" json:"state"`
+
+	LastHeartbeat time.Time `gorm:"column:last_heartbeat" json:"lastHeartbeat"`
+}
+
+// WorkerLoad is the self-reported load of a worker at its last heartbeat.
+type WorkerLoad struct {
+	InflightJobs int     `gorm:"column:inflight_jobs" json:"inflightJobs"`
+	CPU          float64 `gorm:"column:cpu" json:"cpu"`
+	MemPercent   float64 `gorm:"column:mem_percent" json:"memPercent"`
+}
+
+func (WorkerNode) TableName() string {
+	return "worker_node"
+}
+
+// HasLabels reports whether node carries at least the given key/value pairs.
+func (n WorkerNode) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if n.Labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package view
+
+// WorkerHeartbeat is the payload a testplatform worker reports itself with
+// on every heartbeat call.
+type WorkerHeartbeat struct {
+	HostName   string `json:"hostName"`
+	RegionCode string `json:"regionCode"`
+	RegionName string `json:"regionName"`
+	ZoneCode   string `json:"zoneCode"`
+	ZoneName   string `json:"zoneName"`
+	Env        string `json:"env"`
+	IP         string `json:"ip"`
+	Port       int    `json:"port"`
+
+	// InflightJobs, CPU and MemPercent let WorkerPool.Select rank nodes by
+	// load when SelectStrategy is StrategyWeightedRandom or
+	// StrategyLeastLoaded.
+	InflightJobs int     `json:"inflightJobs"`
+	CPU          float64 `json:"cpu"`
+	MemPercent   float64 `json:"memPercent"`
+
+	// Version is the worker's own build version, used by
+	// WorkerPool.SelectVersion for canary/rolling-upgrade routing.
+	Version string `json:"version"`
+
+	// Labels are free-form tags the worker reports about itself (e.g.
+	// "arch=arm64"), used by WorkerPool.SelectWithConstraints to filter
+	// candidates. Omitting this on a given heartbeat does not clear
+	// previously reported labels; see WorkerPool.Heartbeat.
+	Labels map[string]string `json:"labels,omitempty"`
+}